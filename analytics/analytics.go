@@ -0,0 +1,162 @@
+// Package analytics tracks per-slug redirect clicks. Events are recorded
+// asynchronously through a buffered channel so a slow analytics write never
+// adds latency to the redirect path.
+package analytics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"sync"
+	"time"
+)
+
+// Click is a single redirect event. IP is expected to already be truncated
+// or hashed by the caller (see HashIP) before it reaches the recorder, so
+// Recorder never holds a raw client IP.
+type Click struct {
+	Slug      string
+	Timestamp time.Time
+	Referrer  string
+	UserAgent string
+	HashedIP  string
+}
+
+// Stats is the aggregate view returned by GET /api/stats/:slug.
+type Stats struct {
+	TotalHits int64            `json:"totalHits"`
+	LastHit   time.Time        `json:"lastHit"`
+	Hourly    map[string]int64 `json:"hourly"` // last 24h, keyed by RFC3339 hour
+	Daily     map[string]int64 `json:"daily"`  // keyed by YYYY-MM-DD
+}
+
+type slugStats struct {
+	totalHits int64
+	lastHit   time.Time
+	hourly    map[string]int64
+	daily     map[string]int64
+}
+
+// Recorder aggregates clicks in memory. The buffered channel absorbs bursts
+// of redirects; if the worker falls behind, Record drops the event rather
+// than blocking the request path.
+type Recorder struct {
+	events chan Click
+
+	mu    sync.RWMutex
+	stats map[string]*slugStats
+}
+
+// NewRecorder creates a Recorder with the given event buffer size. Call
+// Start to begin processing events.
+func NewRecorder(bufferSize int) *Recorder {
+	return &Recorder{
+		events: make(chan Click, bufferSize),
+		stats:  make(map[string]*slugStats),
+	}
+}
+
+// Start runs the worker loop until ctx is cancelled.
+func (r *Recorder) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case click := <-r.events:
+				r.apply(click)
+			}
+		}
+	}()
+}
+
+// Record enqueues a click for asynchronous processing. It never blocks: if
+// the buffer is full the event is dropped and logged.
+func (r *Recorder) Record(click Click) {
+	select {
+	case r.events <- click:
+	default:
+		log.Printf("analytics: dropping click for slug %q, buffer full", click.Slug)
+	}
+}
+
+func (r *Recorder) apply(click Click) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.stats[click.Slug]
+	if !ok {
+		s = &slugStats{hourly: make(map[string]int64), daily: make(map[string]int64)}
+		r.stats[click.Slug] = s
+	}
+
+	s.totalHits++
+	s.lastHit = click.Timestamp
+	s.hourly[click.Timestamp.UTC().Truncate(time.Hour).Format(time.RFC3339)]++
+	s.daily[click.Timestamp.UTC().Format("2006-01-02")]++
+}
+
+// dailyRetention bounds how long a slug's daily buckets are kept; older
+// buckets are dropped the next time Stats or prune runs for that slug, the
+// same way hourly buckets older than 24h already are.
+const dailyRetention = 90 * 24 * time.Hour
+
+// Stats returns a snapshot of the aggregate click stats for slug, pruning
+// hourly buckets older than 24h and daily buckets older than dailyRetention.
+// The bool is false if no clicks have been recorded for slug yet.
+func (r *Recorder) Stats(slug string) (Stats, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.stats[slug]
+	if !ok {
+		return Stats{}, false
+	}
+	r.prune(s)
+
+	hourly := make(map[string]int64, len(s.hourly))
+	for bucket, count := range s.hourly {
+		hourly[bucket] = count
+	}
+	daily := make(map[string]int64, len(s.daily))
+	for bucket, count := range s.daily {
+		daily[bucket] = count
+	}
+
+	return Stats{
+		TotalHits: s.totalHits,
+		LastHit:   s.lastHit,
+		Hourly:    hourly,
+		Daily:     daily,
+	}, true
+}
+
+// prune drops hourly buckets older than 24h and daily buckets older than
+// dailyRetention from s in place. Callers must hold r.mu for writing.
+func (r *Recorder) prune(s *slugStats) {
+	now := time.Now().UTC()
+	hourlyCutoff := now.Add(-24 * time.Hour)
+	for bucket := range s.hourly {
+		bucketStart, err := time.Parse(time.RFC3339, bucket)
+		if err == nil && bucketStart.Before(hourlyCutoff) {
+			delete(s.hourly, bucket)
+		}
+	}
+
+	dailyCutoff := now.Add(-dailyRetention)
+	for bucket := range s.daily {
+		bucketStart, err := time.Parse("2006-01-02", bucket)
+		if err == nil && bucketStart.Before(dailyCutoff) {
+			delete(s.daily, bucket)
+		}
+	}
+}
+
+// HashIP hashes a client IP with SHA-256 and keeps the first 16 hex
+// characters of the digest, so it can be recorded without retaining the raw
+// address.
+func HashIP(ip string) string {
+	sum := sha256.Sum256([]byte(ip))
+	return hex.EncodeToString(sum[:])[:16]
+}
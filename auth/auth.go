@@ -0,0 +1,75 @@
+// Package auth provides password hashing and JWT issuing/parsing for the
+// authenticated link-management API (POST /api/auth/register, POST
+// /api/auth/login, and the /api/links routes in routes_auth.go).
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials covers both "no such user" and "wrong password" so
+// login responses don't leak which one it was.
+var ErrInvalidCredentials = errors.New("auth: invalid email or password")
+
+// ErrEmailTaken is returned by UserStore.Create on a duplicate email.
+var ErrEmailTaken = errors.New("auth: email already registered")
+
+// User is the storage-agnostic representation of a registered account.
+type User struct {
+	ID           string
+	Email        string
+	PasswordHash string
+}
+
+// Claims is the JWT payload issued on login; UserID is all downstream
+// handlers need to scope a request to its owner.
+type Claims struct {
+	UserID string `json:"userId"`
+	jwt.RegisteredClaims
+}
+
+// TokenTTL is how long an issued JWT remains valid.
+const TokenTTL = 7 * 24 * time.Hour
+
+// HashPassword bcrypt-hashes a plaintext password for storage.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches the stored bcrypt hash.
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// IssueToken signs a Claims token for userID, valid for TokenTTL.
+func IssueToken(secret []byte, userID string) (string, error) {
+	claims := Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
+
+// ParseToken validates and decodes a JWT previously issued by IssueToken.
+func ParseToken(secret []byte, tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return secret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidCredentials
+	}
+	return claims, nil
+}
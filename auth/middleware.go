@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UserIDLocalsKey is the c.Locals key RequireAuth stores the authenticated
+// user's ID under.
+const UserIDLocalsKey = "userID"
+
+// RequireAuth validates an `Authorization: Bearer <token>` header and, on
+// success, stores the token's user ID under UserIDLocalsKey for downstream
+// handlers.
+func RequireAuth(secret []byte) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get("Authorization")
+		tokenString := strings.TrimPrefix(header, "Bearer ")
+		if tokenString == "" || tokenString == header {
+			return c.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
+				"message": "Missing or malformed Authorization header.",
+			})
+		}
+
+		claims, err := ParseToken(secret, tokenString)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
+				"message": "Invalid or expired token.",
+			})
+		}
+
+		c.Locals(UserIDLocalsKey, claims.UserID)
+		return c.Next()
+	}
+}
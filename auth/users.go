@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UserStore persists registered accounts. Unlike link storage this isn't
+// pluggable yet; user accounts for the durable/authenticated API are
+// expected to live in MongoDB regardless of which Store drives the
+// anonymous/ephemeral link endpoints.
+type UserStore interface {
+	Create(ctx context.Context, email, passwordHash string) (User, error)
+	GetByEmail(ctx context.Context, email string) (User, error)
+}
+
+// MongoUserStore is the only UserStore implementation for now.
+type MongoUserStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoUserStore wraps an existing users collection handle.
+func NewMongoUserStore(collection *mongo.Collection) *MongoUserStore {
+	return &MongoUserStore{collection: collection}
+}
+
+// EnsureIndexes creates the unique index on email. Meant to run once at
+// startup, not per request.
+func (s *MongoUserStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (s *MongoUserStore) Create(ctx context.Context, email, passwordHash string) (User, error) {
+	doc := bson.D{
+		{Key: "email", Value: email},
+		{Key: "passwordHash", Value: passwordHash},
+	}
+	result, err := s.collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return User{}, ErrEmailTaken
+	}
+	if err != nil {
+		return User{}, err
+	}
+	return User{
+		ID:           result.InsertedID.(primitive.ObjectID).Hex(),
+		Email:        email,
+		PasswordHash: passwordHash,
+	}, nil
+}
+
+func (s *MongoUserStore) GetByEmail(ctx context.Context, email string) (User, error) {
+	var doc struct {
+		ID           primitive.ObjectID `bson:"_id"`
+		Email        string             `bson:"email"`
+		PasswordHash string             `bson:"passwordHash"`
+	}
+	err := s.collection.FindOne(ctx, bson.D{{Key: "email", Value: email}}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return User{}, err
+	}
+	return User{ID: doc.ID.Hex(), Email: doc.Email, PasswordHash: doc.PasswordHash}, nil
+}
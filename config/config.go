@@ -0,0 +1,104 @@
+// Package config loads dlgfy_api's configuration from a YAML file (see
+// config.example.yml at the repo root), with environment variables taking
+// precedence over file values so existing deployments keep working
+// unchanged.
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type RateLimit struct {
+	Max    int           `mapstructure:"max"`
+	Window time.Duration `mapstructure:"window"`
+}
+
+type CORS struct {
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+}
+
+// URLValidation configures the URLValidator chain run against a submitted
+// URL before it's persisted. See the urlvalidate package.
+type URLValidation struct {
+	RejectPrivateNetworks bool          `mapstructure:"reject_private_networks"`
+	BlocklistFile         string        `mapstructure:"blocklist_file"`
+	SafeBrowsingAPIKey    string        `mapstructure:"safe_browsing_api_key"`
+	CheckReachability     bool          `mapstructure:"check_reachability"`
+	ReachabilityTimeout   time.Duration `mapstructure:"reachability_timeout"`
+}
+
+// Config is the full set of tunables dlgfy_api accepts. Zero values are
+// filled in by the defaults set in Load, so a config file only needs to
+// specify the fields it wants to override.
+type Config struct {
+	HTTPPort      string        `mapstructure:"http_port"`
+	MongoDBURI    string        `mapstructure:"mongodb_uri"`
+	DefaultTTL    time.Duration `mapstructure:"default_ttl"`
+	SlugLength    int           `mapstructure:"slug_length"`
+	SlugAlphabet  string        `mapstructure:"slug_alphabet"`
+	SlugRegex     string        `mapstructure:"slug_regex"`
+	RateLimit     RateLimit     `mapstructure:"rate_limit"`
+	CORS          CORS          `mapstructure:"cors"`
+	ReservedSlugs []string      `mapstructure:"reserved_slugs"`
+	CustomDomain  string        `mapstructure:"custom_domain"`
+	URLValidation URLValidation `mapstructure:"url_validation"`
+}
+
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("http_port", "8000")
+	v.SetDefault("default_ttl", 5*24*time.Hour)
+	v.SetDefault("slug_length", 5)
+	v.SetDefault("slug_alphabet", "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890_-")
+	v.SetDefault("slug_regex", `^[a-zA-Z0-9_-]{3,32}$`)
+	v.SetDefault("rate_limit.max", 20)
+	v.SetDefault("rate_limit.window", time.Minute)
+	v.SetDefault("cors.allowed_origins", []string{"*"})
+	v.SetDefault("reserved_slugs", []string{"api", "admin", "health", "createSlugURLPair", "metrics"})
+	v.SetDefault("url_validation.reject_private_networks", true)
+	v.SetDefault("url_validation.check_reachability", false)
+	v.SetDefault("url_validation.reachability_timeout", 2*time.Second)
+}
+
+// legacyEnvBindings maps the ad-hoc os.Getenv calls this service used to
+// make directly to their config-file equivalents, so operators relying on
+// PORT or MONGODB_URI don't need to migrate to a config file immediately.
+var legacyEnvBindings = map[string]string{
+	"http_port":   "PORT",
+	"mongodb_uri": "MONGODB_URI",
+}
+
+// Load reads configuration from path (if non-empty and present on disk),
+// then layers environment variables on top so they always win over file
+// values. path may be empty, in which case only defaults and env vars
+// apply.
+func Load(path string) (*Config, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	for key, env := range legacyEnvBindings {
+		if err := v.BindEnv(key, env); err != nil {
+			return nil, err
+		}
+	}
+
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			if _, ok := err.(viper.ConfigFileNotFoundError); !ok && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
@@ -3,65 +3,216 @@ package main
 import (
 	"context"
 	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"math/big"
 	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/malekoa/dlgfy_api/analytics"
+	"github.com/malekoa/dlgfy_api/auth"
+	"github.com/malekoa/dlgfy_api/config"
+	"github.com/malekoa/dlgfy_api/metrics"
+	"github.com/malekoa/dlgfy_api/store"
+	"github.com/malekoa/dlgfy_api/urlvalidate"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type URL struct {
-	Value string `json:"url"`
+// CreateSlugURLPairRequest is the body accepted by POST /createSlugURLPair.
+// Slug, TTL and ExpiresAt are all optional; when omitted the service falls
+// back to a generated slug and the configured default TTL.
+type CreateSlugURLPairRequest struct {
+	Value     string     `json:"url"`
+	Slug      string     `json:"slug"`
+	TTL       int64      `json:"ttl"`
+	ExpiresAt *time.Time `json:"expiresAt"`
 }
 
-type SlugURLPair struct {
-	Slug     string    `bson:"slug"`
-	Url      string    `bson:"url"`
-	ExpireAt time.Time `bson:"expireAt"`
+// slugAlphabet is the character set generated slugs are drawn from;
+// overridden from cfg.SlugAlphabet in main before the server starts
+// handling requests.
+var slugAlphabet = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890_-")
+
+// slugRegex constrains user-supplied custom slugs, mirroring the
+// GOREDIRECT_REGEX pattern used in the k-space goredirect service.
+// Overridden from cfg.SlugRegex in main before the server starts handling
+// requests.
+var slugRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{3,32}$`)
+
+// reservedSlugs blocks custom slugs that would collide with routes this
+// service registers itself, plus any words listed under reserved_slugs in
+// config; populated from cfg.ReservedSlugs and the app's actually-registered
+// routes in main.
+var reservedSlugs = map[string]bool{}
+
+// isSlugAllowed reports whether a custom slug passes the format regex and
+// is not on the reserved-word blacklist.
+func isSlugAllowed(slug string) bool {
+	if reservedSlugs[strings.ToLower(slug)] {
+		return false
+	}
+	return slugRegex.MatchString(slug)
 }
 
-var SLUG_ALPHABET = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890_-")
+// reserveRegisteredRoutes adds the first path segment of every route
+// already registered on app to reservedSlugs, so a custom slug can never
+// shadow one of this service's own routes (e.g. "metrics", "api"), even as
+// new routes are added. Parameterized segments (":slug", "*") are skipped
+// since they aren't literal words to reserve.
+func reserveRegisteredRoutes(app *fiber.App) {
+	for _, route := range app.GetRoutes() {
+		segment := strings.TrimPrefix(route.Path, "/")
+		if idx := strings.Index(segment, "/"); idx >= 0 {
+			segment = segment[:idx]
+		}
+		if segment == "" || strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			continue
+		}
+		reservedSlugs[strings.ToLower(segment)] = true
+	}
+}
 
-// Generates a random string of length using the SLUG_ALPHABET
+// Generates a random string of length using slugAlphabet
 func generateRandomString(length int) string {
 	s := make([]rune, length)
 	for i := range s {
-		randomIndex, _ := rand.Int(rand.Reader, big.NewInt(int64(len(SLUG_ALPHABET))))
-		s[i] = SLUG_ALPHABET[randomIndex.Int64()]
+		randomIndex, _ := rand.Int(rand.Reader, big.NewInt(int64(len(slugAlphabet))))
+		s[i] = slugAlphabet[randomIndex.Int64()]
 	}
 	return string(s)
 }
 
-// Generates and returns a 5-character long slug that is not in slugURLPairCollection
-func generateUniqueSlug(slugURLPairCollection *mongo.Collection) string {
-	s := generateRandomString(5)
-	var result bson.M
-	err := slugURLPairCollection.FindOne(context.TODO(), bson.D{{Key: "slug", Value: s}}).Decode(&result)
-	for err == nil {
-		err = slugURLPairCollection.FindOne(context.TODO(), bson.D{{Key: "slug", Value: s}}).Decode(&result)
+// maxSlugGenerationAttempts bounds the insert-with-retry loop below; each
+// attempt grows the slug length by one character.
+const maxSlugGenerationAttempts = 3
+
+// durableLinkTTL is the effective expiration given to links created by an
+// authenticated user that don't specify their own ttl/expiresAt. It's not
+// truly infinite, just far enough out to read as "doesn't expire".
+const durableLinkTTL = 100 * 365 * 24 * time.Hour
+
+// defaultSlugLength is the starting length for generated slugs when
+// cfg.SlugLength isn't set (or Load's default of 5 is in effect).
+const defaultSlugLength = 5
+
+// insertWithUniqueSlug attempts a Put with a freshly generated slug,
+// relying on the store returning ErrSlugTaken to signal a collision instead
+// of checking for existence first (which is inherently racy under
+// concurrent requests). On a collision it retries with a longer slug,
+// starting at startLength characters and growing by one each attempt.
+func insertWithUniqueSlug(ctx context.Context, s store.Store, pair store.SlugURLPair, startLength int) (store.SlugURLPair, error) {
+	if startLength <= 0 {
+		startLength = defaultSlugLength
 	}
-	return s
+	length := startLength
+	for attempt := 0; attempt < maxSlugGenerationAttempts; attempt++ {
+		pair.Slug = generateRandomString(length)
+		err := s.Put(ctx, pair)
+		if err == nil {
+			return pair, nil
+		}
+		if !errors.Is(err, store.ErrSlugTaken) {
+			return store.SlugURLPair{}, err
+		}
+		length++
+	}
+	return store.SlugURLPair{}, fmt.Errorf("failed to generate a unique slug after %d attempts", maxSlugGenerationAttempts)
 }
 
-func createTTLIndex(slugURLPairCollection *mongo.Collection) error {
-	indexModel := mongo.IndexModel{
-		Keys:    bson.D{{Key: "expireAt", Value: 1}},
-		Options: options.Index().SetExpireAfterSeconds(0),
+// newStore selects a Store implementation based on the STORAGE_DRIVER
+// environment variable ("mongo", "memory", or "redis"), defaulting to
+// "mongo" to preserve existing deployments. mongoClient is reused as-is
+// when the driver is "mongo"; it may be nil for the other drivers.
+func newStore(ctx context.Context, mongoClient *mongo.Client) store.Store {
+	driver := os.Getenv("STORAGE_DRIVER")
+	if driver == "" {
+		driver = "mongo"
 	}
-	_, err := slugURLPairCollection.Indexes().CreateOne(context.TODO(), indexModel)
-	if err != nil {
-		log.Panic(err)
+
+	switch driver {
+	case "memory":
+		return store.NewMemoryStore(ctx, time.Minute)
+	case "redis":
+		redisAddr := os.Getenv("REDIS_ADDR")
+		if redisAddr == "" {
+			redisAddr = "localhost:6379"
+		}
+		client := redis.NewClient(&redis.Options{Addr: redisAddr})
+		return store.NewRedisStore(client)
+	case "mongo":
+		if mongoClient == nil {
+			log.Fatal("You must set 'mongodb_uri' in your config file or the 'MONGODB_URI' environmental variable. See\n\t https://www.mongodb.com/docs/drivers/go/current/usage-examples/#environment-variable")
+		}
+		collection := mongoClient.Database("dlgfy").Collection("slug-url-pairs")
+		mongoStore := store.NewMongoStore(collection)
+		if err := mongoStore.EnsureIndexes(ctx); err != nil {
+			log.Fatal(err)
+		}
+		return mongoStore
+	default:
+		log.Fatalf("Unknown STORAGE_DRIVER %q. Must be one of: mongo, memory, redis.", driver)
+		return nil
 	}
-	return err
+}
+
+// resyncActiveSlugs periodically overwrites metrics.ActiveSlugs with an
+// authoritative count from the store, correcting the drift that
+// Inc/Dec-on-create/delete alone can't track (TTL expiry, a Redis key
+// reaped by EX, a process restart). Runs until ctx is cancelled.
+func resyncActiveSlugs(ctx context.Context, counter store.Counter, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := counter.Count(ctx)
+			if err != nil {
+				log.Default().Println("resyncActiveSlugs:", err)
+				continue
+			}
+			metrics.ActiveSlugs.Set(float64(count))
+		}
+	}
+}
+
+// buildValidationChain composes the URLValidator chain run against every
+// submitted URL in /createSlugURLPair, per cfg.URLValidation.
+func buildValidationChain(cfg *config.Config) *urlvalidate.Chain {
+	chain := urlvalidate.NewChain().
+		Add("scheme", urlvalidate.SchemeValidator{RejectPrivateNetworks: cfg.URLValidation.RejectPrivateNetworks})
+
+	if cfg.URLValidation.BlocklistFile != "" {
+		blocklist, err := urlvalidate.LoadBlocklist(cfg.URLValidation.BlocklistFile)
+		if err != nil {
+			log.Fatalf("failed to load url_validation.blocklist_file: %v", err)
+		}
+		chain.Add("blocklist", blocklist)
+	}
+
+	if cfg.URLValidation.SafeBrowsingAPIKey != "" {
+		chain.Add("safe_browsing", urlvalidate.NewSafeBrowsingValidator(cfg.URLValidation.SafeBrowsingAPIKey))
+	}
+
+	if cfg.URLValidation.CheckReachability {
+		chain.Add("reachability", urlvalidate.NewReachabilityValidator(cfg.URLValidation.ReachabilityTimeout))
+	}
+
+	return chain
 }
 
 func main() {
@@ -69,36 +220,79 @@ func main() {
 		log.Println("No .env file found.")
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = ":8000"
-	} else {
-		port = string(":") + port
+	configPath := flag.String("config", "", "path to a config.yml (see config.example.yml); env vars always override it")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	uri := os.Getenv("MONGODB_URI")
-	if uri == "" {
-		log.Fatal("You must set your 'MONGODB_URI' environmental variable. See\n\t https://www.mongodb.com/docs/drivers/go/current/usage-examples/#environment-variable")
+	if cfg.SlugAlphabet != "" {
+		slugAlphabet = []rune(cfg.SlugAlphabet)
+	}
+	if cfg.SlugRegex != "" {
+		compiled, err := regexp.Compile(cfg.SlugRegex)
+		if err != nil {
+			log.Fatalf("invalid slug_regex %q: %v", cfg.SlugRegex, err)
+		}
+		slugRegex = compiled
+	}
+	for _, slug := range cfg.ReservedSlugs {
+		reservedSlugs[strings.ToLower(slug)] = true
 	}
 
-	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(uri))
-	if err != nil {
-		panic(err)
+	port := cfg.HTTPPort
+	if !strings.HasPrefix(port, ":") {
+		port = ":" + port
 	}
 
-	defer func() {
-		if err := client.Disconnect(context.TODO()); err != nil {
+	ctx := context.Background()
+
+	// User accounts for the authenticated /api/* routes always live in
+	// MongoDB, independent of which Store backend drives anonymous links.
+	var mongoClient *mongo.Client
+	var userStore auth.UserStore
+	if cfg.MongoDBURI != "" {
+		var err error
+		mongoClient, err = mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoDBURI))
+		if err != nil {
 			panic(err)
 		}
-	}()
+		mongoUserStore := auth.NewMongoUserStore(mongoClient.Database("dlgfy").Collection("users"))
+		if err := mongoUserStore.EnsureIndexes(ctx); err != nil {
+			log.Fatal(err)
+		}
+		userStore = mongoUserStore
+	} else {
+		log.Println("No 'mongodb_uri' configured; the /api/auth and authenticated /api/links routes are disabled.")
+	}
+
+	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
+	if len(jwtSecret) == 0 {
+		jwtSecret = []byte("dev-secret-change-me")
+		log.Println("No JWT_SECRET set; using an insecure development default. Do not use this in production.")
+	}
+
+	slugStore := newStore(ctx, mongoClient)
+	urlValidation := buildValidationChain(cfg)
+
+	if counter, ok := slugStore.(store.Counter); ok {
+		go resyncActiveSlugs(ctx, counter, time.Minute)
+	}
+
+	clickRecorder := analytics.NewRecorder(256)
+	clickRecorder.Start(ctx)
 
 	app := fiber.New()
 
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{
+		AllowOrigins: strings.Join(cfg.CORS.AllowedOrigins, ","),
+	}))
 
 	app.Use(limiter.New(limiter.Config{
-		Max:               20,
-		Expiration:        1 * time.Minute,
+		Max:               cfg.RateLimit.Max,
+		Expiration:        cfg.RateLimit.Window,
 		LimiterMiddleware: limiter.SlidingWindow{},
 		KeyGenerator: func(c *fiber.Ctx) string {
 			// I have no idea if this is safe, but it works. I'm doing this
@@ -108,9 +302,9 @@ func main() {
 	}))
 
 	app.Post("/createSlugURLPair", func(c *fiber.Ctx) error {
-		// get url from body
-		bodyUrl := new(URL)
-		if err := c.BodyParser(bodyUrl); err != nil {
+		// get url (and optional slug/ttl/expiresAt) from body
+		body := new(CreateSlugURLPairRequest)
+		if err := c.BodyParser(body); err != nil {
 			log.Default().Println(err)
 			return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
 				"err":     err.Error(),
@@ -118,7 +312,7 @@ func main() {
 			})
 		}
 		// ensure url leads with a protocol and that the url leads to a valid location
-		url, err := url.Parse(bodyUrl.Value)
+		parsedUrl, err := url.Parse(body.Value)
 		if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
 				"err":     err.Error(),
@@ -126,53 +320,195 @@ func main() {
 			})
 		}
 		// ensure url has a scheme, default to http
-		if url.Scheme == "" {
-			url.Scheme = "http"
-		}
-		// get slugURLPairCollection
-		slugURLPairCollection := client.Database("dlgfy").Collection("slug-url-pairs")
-		// get unique slug
-		uniqueSlug := generateUniqueSlug(slugURLPairCollection)
-		// set expiration date to 5 days after creation date
-		expireAt := time.Now().UTC().Add(time.Hour * 24 * 5)
-		// set slugURLPair values
-		slugURLPair := SlugURLPair{Slug: uniqueSlug, Url: url.String(), ExpireAt: expireAt}
-		// insert slugURLPair into db
-		result, err := slugURLPairCollection.InsertOne(context.TODO(), slugURLPair)
-		if err != nil {
-			log.Fatal(err)
+		if parsedUrl.Scheme == "" {
+			parsedUrl.Scheme = "http"
 		}
-		// create TTL Index to remove expired SlugURLPairs
-		if err = createTTLIndex(slugURLPairCollection); err != nil {
-			log.Fatal(err)
+
+		if err := urlValidation.Validate(c.Context(), parsedUrl); err != nil {
+			var rejection *urlvalidate.RejectionError
+			message := "URL rejected by validation."
+			if errors.As(err, &rejection) {
+				message = fmt.Sprintf("URL rejected by the %q check.", rejection.Validator)
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+				"err":     err.Error(),
+				"message": message,
+			})
+		}
+
+		// an optional bearer token attributes the link to a registered user,
+		// who gets a far longer default TTL than the anonymous/ephemeral case
+		owner := ""
+		if header := c.Get("Authorization"); header != "" {
+			tokenString := strings.TrimPrefix(header, "Bearer ")
+			if claims, err := auth.ParseToken(jwtSecret, tokenString); err == nil {
+				owner = claims.UserID
+			}
+		}
+
+		// resolve expiration: explicit expiresAt wins, then ttl, then the
+		// configured default TTL (or durableLinkTTL for an owned link)
+		expireAt := time.Now().UTC().Add(cfg.DefaultTTL)
+		if owner != "" {
+			expireAt = time.Now().UTC().Add(durableLinkTTL)
 		}
+		if body.TTL > 0 {
+			expireAt = time.Now().UTC().Add(time.Duration(body.TTL) * time.Second)
+		}
+		if body.ExpiresAt != nil {
+			expireAt = body.ExpiresAt.UTC()
+		}
+
+		managementToken := generateRandomString(32)
+		slugURLPair := store.SlugURLPair{
+			Url:             parsedUrl.String(),
+			ExpireAt:        expireAt,
+			ManagementToken: managementToken,
+			Owner:           owner,
+		}
+
+		if body.Slug != "" {
+			if !isSlugAllowed(body.Slug) {
+				return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+					"message": "Requested slug is invalid or reserved.",
+				})
+			}
+			slugURLPair.Slug = body.Slug
+			if err := slugStore.Put(context.TODO(), slugURLPair); err != nil {
+				if errors.Is(err, store.ErrSlugTaken) {
+					return c.Status(fiber.StatusConflict).JSON(&fiber.Map{
+						"message": "Requested slug is already taken.",
+					})
+				}
+				log.Default().Println(err)
+				return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+					"err":     err.Error(),
+					"message": "Unable to store the requested slug.",
+				})
+			}
+		} else {
+			slugURLPair, err = insertWithUniqueSlug(context.TODO(), slugStore, slugURLPair, cfg.SlugLength)
+			if err != nil {
+				log.Default().Println(err)
+				return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+					"err":     err.Error(),
+					"message": "Unable to generate a unique slug.",
+				})
+			}
+		}
+
+		metrics.SlugCreationsTotal.Inc()
+		metrics.ActiveSlugs.Inc()
+
 		log.Default().Println("Successfully inserted SlugURLPair", slugURLPair)
-		return c.Status(fiber.StatusOK).JSON(&fiber.Map{
-			"result":      result,
+		response := fiber.Map{
 			"slugURLPair": slugURLPair,
-		})
+		}
+		if cfg.CustomDomain != "" {
+			response["shortUrl"] = strings.TrimSuffix(cfg.CustomDomain, "/") + "/" + slugURLPair.Slug
+		}
+		return c.Status(fiber.StatusOK).JSON(&response)
 	})
 
-	// redirects slug to url
-	app.Get("/:slug", func(c *fiber.Ctx) error {
+	// deletes a slugURLPair, guarded by the management token returned at creation
+	app.Delete("/:slug", func(c *fiber.Ctx) error {
 		slugParam := c.Params("slug")
-		slugURLPairCollection := client.Database("dlgfy").Collection("slug-url-pairs")
-		var result SlugURLPair
-		err := slugURLPairCollection.FindOne(context.TODO(), bson.D{{Key: "slug", Value: slugParam}}).Decode(&result)
+		token := c.Get("X-Management-Token")
+		if token == "" {
+			token = c.Query("managementToken")
+		}
+		if token == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
+				"message": "Missing management token.",
+			})
+		}
+		pair, err := slugStore.Get(context.TODO(), slugParam)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
+				"message": "No matching slug for the given management token.",
+			})
+		}
 		if err != nil {
 			log.Default().Println(err)
-			return c.Status(fiber.StatusNotFound).SendString("404: Error - Unable to find redirection URL.")
+			return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+				"err": err.Error(),
+			})
+		}
+		if pair.ManagementToken != token {
+			return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
+				"message": "No matching slug for the given management token.",
+			})
+		}
+		if err := slugStore.Delete(context.TODO(), slugParam); err != nil {
+			log.Default().Println(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+				"err": err.Error(),
+			})
 		}
+		metrics.ActiveSlugs.Dec()
+		return c.SendStatus(fiber.StatusNoContent)
+	})
 
-		log.Default().Println("Successful redirection to", result.Url)
-		return c.Redirect(result.Url)
+	// returns click analytics for a slug
+	app.Get("/api/stats/:slug", func(c *fiber.Ctx) error {
+		slugParam := c.Params("slug")
+		if _, err := slugStore.Get(context.TODO(), slugParam); errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
+				"message": "Unknown slug.",
+			})
+		}
+		stats, ok := clickRecorder.Stats(slugParam)
+		if !ok {
+			return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+				"totalHits": 0,
+				"hourly":    fiber.Map{},
+				"daily":     fiber.Map{},
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(stats)
 	})
 
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+
+	if userStore != nil {
+		registerAuthRoutes(app, userStore, jwtSecret)
+		registerLinksRoutes(app, slugStore, jwtSecret, urlValidation)
+	}
+
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusOK).JSON(&fiber.Map{
 			"message": "Hello Delongify!",
 		})
 	})
 
+	// Reserve every route this instance actually registered, on top of the
+	// static reserved_slugs list, so a custom slug can never shadow one of
+	// the app's own routes (including routes added later).
+	reserveRegisteredRoutes(app)
+
+	// redirects slug to url; registered last since Fiber matches routes in
+	// registration order and this catch-all ("/:slug") would otherwise
+	// shadow every static route registered after it (e.g. /metrics).
+	app.Get("/:slug", func(c *fiber.Ctx) error {
+		slugParam := c.Params("slug")
+		result, err := slugStore.Get(context.TODO(), slugParam)
+		if err != nil {
+			log.Default().Println(err)
+			return c.Status(fiber.StatusNotFound).SendString("404: Error - Unable to find redirection URL.")
+		}
+
+		metrics.RedirectsTotal.Inc()
+		clickRecorder.Record(analytics.Click{
+			Slug:      slugParam,
+			Timestamp: time.Now().UTC(),
+			Referrer:  c.Get("Referer"),
+			UserAgent: c.Get("User-Agent"),
+			HashedIP:  analytics.HashIP(c.IP()),
+		})
+
+		log.Default().Println("Successful redirection to", result.Url)
+		return c.Redirect(result.Url)
+	})
+
 	log.Fatal(app.Listen(port))
 }
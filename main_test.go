@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/malekoa/dlgfy_api/store"
+)
+
+// fakeStore lets tests control exactly how many Put calls collide before
+// succeeding, without needing a real MongoDB/Redis connection.
+type fakeStore struct {
+	collisionsBeforeSuccess int
+	puts                    []store.SlugURLPair
+}
+
+func (f *fakeStore) Put(ctx context.Context, pair store.SlugURLPair) error {
+	f.puts = append(f.puts, pair)
+	if len(f.puts) <= f.collisionsBeforeSuccess {
+		return store.ErrSlugTaken
+	}
+	return nil
+}
+
+func (f *fakeStore) Get(ctx context.Context, slug string) (store.SlugURLPair, error) {
+	return store.SlugURLPair{}, store.ErrNotFound
+}
+
+func (f *fakeStore) Delete(ctx context.Context, slug string) error { return nil }
+
+func (f *fakeStore) Exists(ctx context.Context, slug string) (bool, error) { return false, nil }
+
+func TestInsertWithUniqueSlug_RetriesOnCollision(t *testing.T) {
+	fs := &fakeStore{collisionsBeforeSuccess: 2}
+	pair, err := insertWithUniqueSlug(context.Background(), fs, store.SlugURLPair{Url: "http://example.com"}, defaultSlugLength)
+	if err != nil {
+		t.Fatalf("expected success after retries, got error: %v", err)
+	}
+	if len(fs.puts) != 3 {
+		t.Fatalf("expected 3 Put attempts (2 collisions + 1 success), got %d", len(fs.puts))
+	}
+	if got, want := len(fs.puts[0].Slug), 5; got != want {
+		t.Errorf("first attempt slug length = %d, want %d", got, want)
+	}
+	if got, want := len(fs.puts[2].Slug), 7; got != want {
+		t.Errorf("third attempt slug length = %d, want %d", got, want)
+	}
+	if pair.Slug != fs.puts[2].Slug {
+		t.Errorf("returned pair slug %q does not match the successful Put's slug %q", pair.Slug, fs.puts[2].Slug)
+	}
+}
+
+func TestInsertWithUniqueSlug_ExhaustsAttempts(t *testing.T) {
+	fs := &fakeStore{collisionsBeforeSuccess: maxSlugGenerationAttempts}
+	_, err := insertWithUniqueSlug(context.Background(), fs, store.SlugURLPair{Url: "http://example.com"}, defaultSlugLength)
+	if err == nil {
+		t.Fatal("expected an error once every attempt collides")
+	}
+	if len(fs.puts) != maxSlugGenerationAttempts {
+		t.Fatalf("expected exactly %d attempts, got %d", maxSlugGenerationAttempts, len(fs.puts))
+	}
+}
+
+// erroringStore always returns a non-collision error, to verify
+// insertWithUniqueSlug only retries on store.ErrSlugTaken.
+type erroringStore struct {
+	err error
+}
+
+func (s *erroringStore) Put(ctx context.Context, pair store.SlugURLPair) error { return s.err }
+func (s *erroringStore) Get(ctx context.Context, slug string) (store.SlugURLPair, error) {
+	return store.SlugURLPair{}, store.ErrNotFound
+}
+func (s *erroringStore) Delete(ctx context.Context, slug string) error         { return nil }
+func (s *erroringStore) Exists(ctx context.Context, slug string) (bool, error) { return false, nil }
+
+func TestInsertWithUniqueSlug_PropagatesNonCollisionErrors(t *testing.T) {
+	fs := &erroringStore{err: errors.New("boom")}
+	_, err := insertWithUniqueSlug(context.Background(), fs, store.SlugURLPair{}, defaultSlugLength)
+	if !errors.Is(err, fs.err) {
+		t.Fatalf("expected the underlying error to propagate unwrapped, got %v", err)
+	}
+	if len(fs.err.Error()) == 0 {
+		t.Fatal("sanity check: erroringStore.err should be non-empty")
+	}
+}
+
+// BenchmarkInsertWithUniqueSlug exercises the hot insert-with-retry path
+// against an in-memory Store. Unlike the original
+// generateUniqueSlug/createTTLIndex pairing, there's no
+// Indexes().CreateOne call anywhere on this path: index setup now happens
+// once in newStore at startup via EnsureIndexes.
+func BenchmarkInsertWithUniqueSlug(b *testing.B) {
+	s := store.NewMemoryStore(context.Background(), time.Hour)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := insertWithUniqueSlug(context.Background(), s, store.SlugURLPair{Url: "http://example.com"}, defaultSlugLength); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
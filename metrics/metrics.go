@@ -0,0 +1,29 @@
+// Package metrics holds the Prometheus collectors exported on /metrics.
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	RedirectsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "dlgfy_redirects_total",
+		Help: "Total number of slug redirects served.",
+	})
+
+	SlugCreationsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "dlgfy_slug_creations_total",
+		Help: "Total number of slug/URL pairs created.",
+	})
+
+	// ActiveSlugs is kept close to correct by Inc/Dec calls around create and
+	// explicit delete, but those alone drift on TTL/sweep expiry and reset
+	// to 0 across a restart; callers should periodically resync it from an
+	// authoritative count (see store.Counter) rather than trust it exactly.
+	ActiveSlugs = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "dlgfy_active_slugs",
+		Help: "Approximate number of non-expired slugs known to this instance, periodically resynced from the store.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(RedirectsTotal, SlugCreationsTotal, ActiveSlugs)
+}
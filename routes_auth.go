@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/malekoa/dlgfy_api/auth"
+	"github.com/malekoa/dlgfy_api/metrics"
+	"github.com/malekoa/dlgfy_api/store"
+	"github.com/malekoa/dlgfy_api/urlvalidate"
+)
+
+// registerAuthCredentials is the body accepted by both
+// POST /api/auth/register and POST /api/auth/login.
+type registerAuthCredentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// registerAuthRoutes wires up POST /api/auth/register and
+// POST /api/auth/login.
+func registerAuthRoutes(app *fiber.App, userStore auth.UserStore, jwtSecret []byte) {
+	app.Post("/api/auth/register", func(c *fiber.Ctx) error {
+		body := new(registerAuthCredentials)
+		if err := c.BodyParser(body); err != nil || body.Email == "" || body.Password == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+				"message": "Body must contain `email` and `password` fields.",
+			})
+		}
+
+		passwordHash, err := auth.HashPassword(body.Password)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+				"err": err.Error(),
+			})
+		}
+
+		user, err := userStore.Create(context.TODO(), body.Email, passwordHash)
+		if err != nil {
+			if errors.Is(err, auth.ErrEmailTaken) {
+				return c.Status(fiber.StatusConflict).JSON(&fiber.Map{
+					"message": "Email is already registered.",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+				"err": err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(&fiber.Map{
+			"id":    user.ID,
+			"email": user.Email,
+		})
+	})
+
+	app.Post("/api/auth/login", func(c *fiber.Ctx) error {
+		body := new(registerAuthCredentials)
+		if err := c.BodyParser(body); err != nil || body.Email == "" || body.Password == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+				"message": "Body must contain `email` and `password` fields.",
+			})
+		}
+
+		user, err := userStore.GetByEmail(context.TODO(), body.Email)
+		if err != nil || !auth.CheckPassword(user.PasswordHash, body.Password) {
+			return c.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
+				"message": "Invalid email or password.",
+			})
+		}
+
+		token, err := auth.IssueToken(jwtSecret, user.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+				"err": err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+			"token": token,
+		})
+	})
+}
+
+// patchLinkRequest is the body accepted by PATCH /api/links/:slug.
+type patchLinkRequest struct {
+	Url string `json:"url"`
+}
+
+// registerLinksRoutes wires up the authenticated GET/PATCH/DELETE
+// /api/links routes. Listing and rotating a link's target URL both require
+// the backing Store to implement store.OwnerAware; if it doesn't (the
+// Redis backend, currently), these routes respond 501. urlValidation is run
+// against a rotated URL exactly as it is against a URL submitted at create
+// time.
+func registerLinksRoutes(app *fiber.App, slugStore store.Store, jwtSecret []byte, urlValidation *urlvalidate.Chain) {
+	links := app.Group("/api/links", auth.RequireAuth(jwtSecret))
+
+	links.Get("/", func(c *fiber.Ctx) error {
+		ownerStore, ok := slugStore.(store.OwnerAware)
+		if !ok {
+			return c.Status(fiber.StatusNotImplemented).JSON(&fiber.Map{
+				"message": "The configured storage backend doesn't support listing links by owner.",
+			})
+		}
+		owner := c.Locals(auth.UserIDLocalsKey).(string)
+		pairs, err := ownerStore.ListByOwner(context.TODO(), owner)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+				"err": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(&fiber.Map{
+			"links": pairs,
+		})
+	})
+
+	links.Patch("/:slug", func(c *fiber.Ctx) error {
+		ownerStore, ok := slugStore.(store.OwnerAware)
+		if !ok {
+			return c.Status(fiber.StatusNotImplemented).JSON(&fiber.Map{
+				"message": "The configured storage backend doesn't support rotating a link's URL.",
+			})
+		}
+		body := new(patchLinkRequest)
+		if err := c.BodyParser(body); err != nil || body.Url == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+				"message": "Body must contain a `url` field.",
+			})
+		}
+
+		parsedUrl, err := url.Parse(body.Url)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+				"err":     err.Error(),
+				"message": "Invalid URL.",
+			})
+		}
+		if parsedUrl.Scheme == "" {
+			parsedUrl.Scheme = "http"
+		}
+		if err := urlValidation.Validate(c.Context(), parsedUrl); err != nil {
+			var rejection *urlvalidate.RejectionError
+			message := "URL rejected by validation."
+			if errors.As(err, &rejection) {
+				message = fmt.Sprintf("URL rejected by the %q check.", rejection.Validator)
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+				"err":     err.Error(),
+				"message": message,
+			})
+		}
+
+		owner := c.Locals(auth.UserIDLocalsKey).(string)
+		err = ownerStore.UpdateURL(context.TODO(), c.Params("slug"), owner, parsedUrl.String())
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
+				"message": "No matching link for this account.",
+			})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+				"err": err.Error(),
+			})
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	links.Delete("/:slug", func(c *fiber.Ctx) error {
+		owner := c.Locals(auth.UserIDLocalsKey).(string)
+		slug := c.Params("slug")
+
+		pair, err := slugStore.Get(context.TODO(), slug)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && pair.Owner != owner) {
+			return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
+				"message": "No matching link for this account.",
+			})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+				"err": err.Error(),
+			})
+		}
+
+		if err := slugStore.Delete(context.TODO(), slug); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+				"err": err.Error(),
+			})
+		}
+		metrics.ActiveSlugs.Dec()
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store backed by a map, intended for tests
+// and single-node deployments that don't want a MongoDB dependency. A
+// background goroutine sweeps expired pairs so long-running processes don't
+// leak memory.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	pairs map[string]SlugURLPair
+}
+
+// NewMemoryStore starts the expiration sweep goroutine and returns a ready
+// to use store. The sweep runs until ctx is cancelled.
+func NewMemoryStore(ctx context.Context, sweepInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{pairs: make(map[string]SlugURLPair)}
+	go s.sweepExpired(ctx, sweepInterval)
+	return s
+}
+
+func (s *MemoryStore) sweepExpired(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now().UTC()
+			s.mu.Lock()
+			for slug, pair := range s.pairs {
+				if !pair.ExpireAt.IsZero() && now.After(pair.ExpireAt) {
+					delete(s.pairs, slug)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, pair SlugURLPair) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.pairs[pair.Slug]; exists {
+		return ErrSlugTaken
+	}
+	s.pairs[pair.Slug] = pair
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, slug string) (SlugURLPair, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	pair, ok := s.pairs[slug]
+	if !ok || isExpired(pair) {
+		return SlugURLPair{}, ErrNotFound
+	}
+	return pair, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, slug string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pairs[slug]; !ok {
+		return ErrNotFound
+	}
+	delete(s.pairs, slug)
+	return nil
+}
+
+func (s *MemoryStore) Exists(ctx context.Context, slug string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	pair, ok := s.pairs[slug]
+	return ok && !isExpired(pair), nil
+}
+
+// isExpired reports whether pair's ExpireAt has already passed. Get and
+// Exists check this inline rather than relying solely on sweepExpired,
+// which only runs once per sweepInterval and would otherwise let an
+// already-expired pair through for up to that long.
+func isExpired(pair SlugURLPair) bool {
+	return !pair.ExpireAt.IsZero() && time.Now().UTC().After(pair.ExpireAt)
+}
+
+// Count implements Counter.
+func (s *MemoryStore) Count(ctx context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var count int64
+	for _, pair := range s.pairs {
+		if !isExpired(pair) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListByOwner implements OwnerAware.
+func (s *MemoryStore) ListByOwner(ctx context.Context, owner string) ([]SlugURLPair, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var pairs []SlugURLPair
+	for _, pair := range s.pairs {
+		if pair.Owner == owner {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs, nil
+}
+
+// UpdateURL implements OwnerAware.
+func (s *MemoryStore) UpdateURL(ctx context.Context, slug, owner, newURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pair, ok := s.pairs[slug]
+	if !ok || pair.Owner != owner {
+		return ErrNotFound
+	}
+	pair.Url = newURL
+	s.pairs[slug] = pair
+	return nil
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore persists pairs in a MongoDB collection. It expects
+// EnsureIndexes to have been called once at startup.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore wraps an existing collection handle.
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// EnsureIndexes creates the TTL index on expireAt and the unique index on
+// slug. It is idempotent and meant to run once during startup, not per
+// request.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	if _, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expireAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}); err != nil {
+		return err
+	}
+	if _, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *MongoStore) Put(ctx context.Context, pair SlugURLPair) error {
+	doc := bson.D{
+		{Key: "slug", Value: pair.Slug},
+		{Key: "url", Value: pair.Url},
+		{Key: "expireAt", Value: pair.ExpireAt},
+		{Key: "managementToken", Value: pair.ManagementToken},
+		{Key: "owner", Value: pair.Owner},
+	}
+	_, err := s.collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrSlugTaken
+	}
+	return err
+}
+
+// Count implements Counter. The TTL index means expired pairs are already
+// reaped by MongoDB itself, so a plain count is an accurate live total.
+func (s *MongoStore) Count(ctx context.Context) (int64, error) {
+	return s.collection.CountDocuments(ctx, bson.D{})
+}
+
+// ListByOwner returns every pair owned by owner. Implements OwnerAware.
+func (s *MongoStore) ListByOwner(ctx context.Context, owner string) ([]SlugURLPair, error) {
+	cursor, err := s.collection.Find(ctx, bson.D{{Key: "owner", Value: owner}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var pairs []SlugURLPair
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, decodePair(doc))
+	}
+	return pairs, cursor.Err()
+}
+
+// UpdateURL implements OwnerAware.
+func (s *MongoStore) UpdateURL(ctx context.Context, slug, owner, newURL string) error {
+	result, err := s.collection.UpdateOne(ctx,
+		bson.D{{Key: "slug", Value: slug}, {Key: "owner", Value: owner}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "url", Value: newURL}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) Get(ctx context.Context, slug string) (SlugURLPair, error) {
+	var result bson.M
+	err := s.collection.FindOne(ctx, bson.D{{Key: "slug", Value: slug}}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return SlugURLPair{}, ErrNotFound
+	}
+	if err != nil {
+		return SlugURLPair{}, err
+	}
+	return decodePair(result), nil
+}
+
+func (s *MongoStore) Delete(ctx context.Context, slug string) error {
+	result, err := s.collection.DeleteOne(ctx, bson.D{{Key: "slug", Value: slug}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) Exists(ctx context.Context, slug string) (bool, error) {
+	count, err := s.collection.CountDocuments(ctx, bson.D{{Key: "slug", Value: slug}})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// decodePair pulls a SlugURLPair out of a raw bson.M document.
+func decodePair(doc bson.M) SlugURLPair {
+	pair := SlugURLPair{}
+	if v, ok := doc["slug"].(string); ok {
+		pair.Slug = v
+	}
+	if v, ok := doc["url"].(string); ok {
+		pair.Url = v
+	}
+	if v, ok := doc["managementToken"].(string); ok {
+		pair.ManagementToken = v
+	}
+	if v, ok := doc["owner"].(string); ok {
+		pair.Owner = v
+	}
+	switch v := doc["expireAt"].(type) {
+	case time.Time:
+		pair.ExpireAt = v
+	case primitiveDateTime:
+		pair.ExpireAt = v.Time()
+	}
+	return pair
+}
+
+// primitiveDateTime is satisfied by bson.DateTime without importing the
+// primitive package directly in this file's type switch.
+type primitiveDateTime interface {
+	Time() time.Time
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisValue is what gets JSON-encoded into the Redis string value; the
+// slug itself is the key, and expiration is handled natively via EX. Owner
+// is carried through so DELETE's ownership check on routes that don't
+// require store.OwnerAware still works against a redis-backed Store.
+type redisValue struct {
+	Url             string `json:"url"`
+	ManagementToken string `json:"managementToken"`
+	Owner           string `json:"owner"`
+}
+
+// RedisStore persists pairs as `SET slug value EX ttl`, letting Redis own
+// expiration instead of a TTL index or sweep goroutine.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore wraps an existing client.
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Put(ctx context.Context, pair SlugURLPair) error {
+	value, err := json.Marshal(redisValue{Url: pair.Url, ManagementToken: pair.ManagementToken, Owner: pair.Owner})
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(pair.ExpireAt)
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+	ok, err := s.client.SetNX(ctx, pair.Slug, value, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSlugTaken
+	}
+	return nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, slug string) (SlugURLPair, error) {
+	raw, err := s.client.Get(ctx, slug).Result()
+	if err == redis.Nil {
+		return SlugURLPair{}, ErrNotFound
+	}
+	if err != nil {
+		return SlugURLPair{}, err
+	}
+	var value redisValue
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return SlugURLPair{}, err
+	}
+	ttl, err := s.client.TTL(ctx, slug).Result()
+	if err != nil {
+		return SlugURLPair{}, err
+	}
+	return SlugURLPair{
+		Slug:            slug,
+		Url:             value.Url,
+		ManagementToken: value.ManagementToken,
+		Owner:           value.Owner,
+		ExpireAt:        time.Now().Add(ttl),
+	}, nil
+}
+
+func (s *RedisStore) Delete(ctx context.Context, slug string) error {
+	deleted, err := s.client.Del(ctx, slug).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Count implements Counter via DBSIZE. This assumes the Redis database is
+// dedicated to dlgfy_api slugs, which already holds for every other
+// RedisStore operation here (slugs are stored as bare top-level keys).
+func (s *RedisStore) Count(ctx context.Context) (int64, error) {
+	return s.client.DBSize(ctx).Result()
+}
+
+func (s *RedisStore) Exists(ctx context.Context, slug string) (bool, error) {
+	count, err := s.client.Exists(ctx, slug).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
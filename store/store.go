@@ -0,0 +1,59 @@
+// Package store abstracts the persistence of slug/URL pairs behind a small
+// interface so the HTTP layer in main.go doesn't need to know whether pairs
+// live in MongoDB, an in-process map, or Redis.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Get and Delete when no pair exists for a slug.
+var ErrNotFound = errors.New("store: slug not found")
+
+// ErrSlugTaken is returned by Put when the slug already exists. Callers use
+// this as the collision signal for retry-with-longer-slug logic instead of
+// checking existence before inserting.
+var ErrSlugTaken = errors.New("store: slug already taken")
+
+// SlugURLPair is the storage-agnostic representation of a shortened link.
+// Owner is empty for anonymous/ephemeral links created via
+// /createSlugURLPair, and set to the creating user's ID for links created
+// through the authenticated /api/links API.
+type SlugURLPair struct {
+	Slug            string
+	Url             string
+	ExpireAt        time.Time
+	ManagementToken string
+	Owner           string
+}
+
+// Store is implemented by every storage backend dlgfy_api can run against.
+// Put is atomic: it must fail with ErrSlugTaken rather than overwrite an
+// existing pair, so callers can use it as a compare-and-swap primitive.
+type Store interface {
+	Put(ctx context.Context, pair SlugURLPair) error
+	Get(ctx context.Context, slug string) (SlugURLPair, error)
+	Delete(ctx context.Context, slug string) error
+	Exists(ctx context.Context, slug string) (bool, error)
+}
+
+// Counter is implemented by stores that can cheaply report how many
+// non-expired pairs they currently hold. Used to periodically resync the
+// dlgfy_active_slugs gauge, since incremental Inc/Dec calls alone drift
+// after a TTL expiry or a process restart.
+type Counter interface {
+	Count(ctx context.Context) (int64, error)
+}
+
+// OwnerAware is implemented by stores that can list and mutate pairs scoped
+// to an owner, used by the authenticated /api/links routes. Not every Store
+// backend supports it (RedisStore doesn't, for one) so handlers type-assert
+// before use and return 501 when it's missing.
+type OwnerAware interface {
+	ListByOwner(ctx context.Context, owner string) ([]SlugURLPair, error)
+	// UpdateURL rewrites the target URL for slug, but only if it is owned
+	// by owner. Returns ErrNotFound otherwise.
+	UpdateURL(ctx context.Context, slug, owner, newURL string) error
+}
@@ -0,0 +1,56 @@
+package urlvalidate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// BlocklistValidator rejects URLs whose host matches an entry in a local
+// blocklist file, one hostname per line (blank lines and lines starting
+// with '#' are ignored). A host matches if it equals an entry or is a
+// subdomain of one.
+type BlocklistValidator struct {
+	hosts map[string]bool
+}
+
+// LoadBlocklist reads a hostname blocklist file.
+func LoadBlocklist(path string) (*BlocklistValidator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hosts := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hosts[strings.ToLower(line)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &BlocklistValidator{hosts: hosts}, nil
+}
+
+func (v *BlocklistValidator) Validate(ctx context.Context, u *url.URL) error {
+	host := strings.ToLower(u.Hostname())
+	for candidate := host; candidate != ""; {
+		if v.hosts[candidate] {
+			return fmt.Errorf("host %q is on the blocklist", host)
+		}
+		dot := strings.Index(candidate, ".")
+		if dot == -1 {
+			break
+		}
+		candidate = candidate[dot+1:]
+	}
+	return nil
+}
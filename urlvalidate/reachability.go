@@ -0,0 +1,45 @@
+package urlvalidate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ReachabilityValidator issues a short-timeout HEAD request to confirm a
+// URL actually resolves to something before it's shortened.
+type ReachabilityValidator struct {
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+// NewReachabilityValidator returns a validator bounded by timeout.
+func NewReachabilityValidator(timeout time.Duration) *ReachabilityValidator {
+	return &ReachabilityValidator{
+		Timeout: timeout,
+		Client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (v *ReachabilityValidator) Validate(ctx context.Context, u *url.URL) error {
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("url is not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("url responded with server error status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package urlvalidate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const safeBrowsingEndpoint = "https://safebrowsing.googleapis.com/v4/threatMatches:find"
+
+// SafeBrowsingValidator rejects URLs flagged by the Google Safe Browsing
+// v4 API. See https://developers.google.com/safe-browsing/v4.
+type SafeBrowsingValidator struct {
+	APIKey string
+	Client *http.Client
+}
+
+// NewSafeBrowsingValidator returns a validator using http.DefaultClient.
+func NewSafeBrowsingValidator(apiKey string) *SafeBrowsingValidator {
+	return &SafeBrowsingValidator{APIKey: apiKey, Client: http.DefaultClient}
+}
+
+type safeBrowsingRequest struct {
+	Client     safeBrowsingClientInfo `json:"client"`
+	ThreatInfo safeBrowsingThreatInfo `json:"threatInfo"`
+}
+
+type safeBrowsingClientInfo struct {
+	ClientID      string `json:"clientId"`
+	ClientVersion string `json:"clientVersion"`
+}
+
+type safeBrowsingThreatInfo struct {
+	ThreatTypes      []string            `json:"threatTypes"`
+	PlatformTypes    []string            `json:"platformTypes"`
+	ThreatEntryTypes []string            `json:"threatEntryTypes"`
+	ThreatEntries    []map[string]string `json:"threatEntries"`
+}
+
+type safeBrowsingResponse struct {
+	Matches []json.RawMessage `json:"matches"`
+}
+
+func (v *SafeBrowsingValidator) Validate(ctx context.Context, u *url.URL) error {
+	reqBody := safeBrowsingRequest{
+		Client: safeBrowsingClientInfo{ClientID: "dlgfy_api", ClientVersion: "1.0"},
+		ThreatInfo: safeBrowsingThreatInfo{
+			ThreatTypes:      []string{"MALWARE", "SOCIAL_ENGINEERING", "UNWANTED_SOFTWARE"},
+			PlatformTypes:    []string{"ANY_PLATFORM"},
+			ThreatEntryTypes: []string{"URL"},
+			ThreatEntries:    []map[string]string{{"url": u.String()}},
+		},
+	}
+
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+
+	endpoint := safeBrowsingEndpoint + "?key=" + v.APIKey
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.Client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("safe browsing lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("safe browsing lookup returned status %d", resp.StatusCode)
+	}
+
+	var sbResp safeBrowsingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&sbResp); err != nil {
+		return fmt.Errorf("safe browsing lookup returned an unreadable response: %w", err)
+	}
+
+	if len(sbResp.Matches) > 0 {
+		return fmt.Errorf("url is flagged by Google Safe Browsing")
+	}
+	return nil
+}
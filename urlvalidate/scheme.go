@@ -0,0 +1,47 @@
+package urlvalidate
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// SchemeValidator rejects anything that isn't http(s) and, when
+// RejectPrivateNetworks is set, resolves the host and rejects it if any
+// resolved address is private/loopback/link-local — a basic SSRF guard
+// against links that point at internal infrastructure.
+type SchemeValidator struct {
+	RejectPrivateNetworks bool
+}
+
+func (v SchemeValidator) Validate(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme %q is not allowed, only http and https are", u.Scheme)
+	}
+
+	if !v.RejectPrivateNetworks {
+		return nil
+	}
+
+	host := u.Hostname()
+	var resolver net.Resolver
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("could not resolve host %q: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if isPrivateOrLoopback(addr.IP) {
+			return fmt.Errorf("host %q resolves to a private/loopback address (%s)", host, addr.IP)
+		}
+	}
+	return nil
+}
+
+func isPrivateOrLoopback(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified()
+}
@@ -0,0 +1,66 @@
+// Package urlvalidate runs a submitted URL through a pluggable chain of
+// checks before it's persisted as a slug target: scheme/SSRF checks, an
+// optional blocklist or Safe Browsing lookup, and an optional reachability
+// probe. Each check is a URLValidator; main.go composes the chain it wants
+// from config.
+package urlvalidate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// URLValidator is one check in the validation chain.
+type URLValidator interface {
+	Validate(ctx context.Context, u *url.URL) error
+}
+
+// RejectionError identifies which validator in a Chain rejected a URL, so
+// handlers can return a structured response naming the offending check.
+type RejectionError struct {
+	Validator string
+	Err       error
+}
+
+func (e *RejectionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Validator, e.Err)
+}
+
+func (e *RejectionError) Unwrap() error {
+	return e.Err
+}
+
+// namedValidator pairs a validator with the name reported in RejectionError.
+type namedValidator struct {
+	name      string
+	validator URLValidator
+}
+
+// Chain runs a sequence of named validators in order, stopping at the
+// first rejection.
+type Chain struct {
+	validators []namedValidator
+}
+
+// NewChain builds a Chain from nothing; use Add to compose it.
+func NewChain() *Chain {
+	return &Chain{}
+}
+
+// Add appends a validator to the chain under the given name.
+func (c *Chain) Add(name string, v URLValidator) *Chain {
+	c.validators = append(c.validators, namedValidator{name: name, validator: v})
+	return c
+}
+
+// Validate runs every validator in the chain, returning a *RejectionError
+// naming the first one that rejects u.
+func (c *Chain) Validate(ctx context.Context, u *url.URL) error {
+	for _, nv := range c.validators {
+		if err := nv.validator.Validate(ctx, u); err != nil {
+			return &RejectionError{Validator: nv.name, Err: err}
+		}
+	}
+	return nil
+}